@@ -0,0 +1,92 @@
+// Package content extracts searchable text bodies out of files so findex
+// can match queries against contents, not just filenames.
+package content
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Extractors maps a lowercase extension to the function that pulls UTF-8
+// text out of a file with that extension. New formats plug in by adding an
+// entry here.
+var Extractors = map[string]func(path string) (string, error){
+	"txt":  extractPlainText,
+	"md":   extractPlainText,
+	"pdf":  extractPDF,
+	"docx": extractDocx,
+}
+
+// Supported reports whether ext has a registered extractor.
+func Supported(ext string) bool {
+	_, ok := Extractors[ext]
+	return ok
+}
+
+func extractPlainText(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func extractPDF(path string) (string, error) {
+	out, err := exec.Command("pdftotext", path, "-").Output()
+	if err != nil {
+		return "", fmt.Errorf("pdftotext: %w", err)
+	}
+	return string(out), nil
+}
+
+// extractDocx pulls the document body out of a .docx (a zip archive
+// containing word/document.xml) with `unzip -p`, then strips XML tags with
+// a small hand-rolled scanner rather than pulling in a full XML parser for
+// one string extraction.
+func extractDocx(path string) (string, error) {
+	out, err := exec.Command("unzip", "-p", path, "word/document.xml").Output()
+	if err != nil {
+		return "", fmt.Errorf("unzip docx: %w", err)
+	}
+	return stripTags(string(out)), nil
+}
+
+func stripTags(xml string) string {
+	var b strings.Builder
+	b.Grow(len(xml))
+	inTag := false
+	for _, r := range xml {
+		switch {
+		case r == '<':
+			inTag = true
+		case r == '>':
+			inTag = false
+			b.WriteRune(' ')
+		case !inTag:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Fingerprint hashes a file's contents so extraction can be skipped when the
+// file hasn't actually changed even if mtime shifted.
+func Fingerprint(path string) (sha256Hex string, size int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
@@ -0,0 +1,171 @@
+package content
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// candidate is one files row eligible for content extraction.
+type candidate struct {
+	id      int64
+	path    string
+	ext     string
+	oldSHA  string
+	oldSize int64
+}
+
+// IndexBodies extracts bodies for every content-eligible file whose
+// fingerprint (sha256+size) doesn't match what's already stored, and writes
+// them into files_fts. It runs in a worker pool bounded by workers, and each
+// file is committed in its own short transaction so content extraction never
+// holds up `findex index`'s filename transaction — filename rows land
+// first, bodies are filled in asynchronously afterward. progress, if
+// non-nil, is called after every file (whether or not its body actually
+// changed).
+func IndexBodies(conn *sql.DB, workers int, progress func(done, total int64)) (int64, error) {
+	if workers <= 0 {
+		workers = 4
+	}
+
+	// files_fts only exists when the linked sqlite3 was built with FTS5
+	// (see db.DB.ContentSearchAvailable). Check once up front instead of
+	// letting every candidate's storeBody fail its own INSERT against a
+	// missing table.
+	if !ftsAvailable(conn) {
+		fmt.Fprintln(os.Stderr, "content: files_fts unavailable (sqlite3 built without sqlite_fts5); skipping body extraction")
+		return 0, nil
+	}
+
+	cands, err := pendingCandidates(conn)
+	if err != nil {
+		return 0, err
+	}
+	total := int64(len(cands))
+
+	jobs := make(chan candidate)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var done, extracted int64
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				if storeBody(conn, c) {
+					mu.Lock()
+					extracted++
+					mu.Unlock()
+				}
+				mu.Lock()
+				done++
+				if progress != nil {
+					progress(done, total)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, c := range cands {
+		jobs <- c
+	}
+	close(jobs)
+	wg.Wait()
+
+	return extracted, nil
+}
+
+// ftsAvailable reports whether files_fts exists, mirroring the check
+// search.Search does before relying on it.
+func ftsAvailable(conn *sql.DB) bool {
+	var name string
+	err := conn.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'files_fts'`).Scan(&name)
+	return err == nil
+}
+
+// pendingCandidates lists every indexed, non-directory file whose extension
+// has a registered extractor.
+func pendingCandidates(conn *sql.DB) ([]candidate, error) {
+	exts := make([]string, 0, len(Extractors))
+	for e := range Extractors {
+		exts = append(exts, e)
+	}
+	placeholders := make([]string, len(exts))
+	args := make([]any, len(exts))
+	for i, e := range exts {
+		placeholders[i] = "?"
+		args[i] = e
+	}
+
+	rows, err := conn.Query(fmt.Sprintf(`
+		SELECT id, path, ext, COALESCE(sha256, ''), size
+		FROM files
+		WHERE is_dir = 0 AND ext IN (%s)
+	`, strings.Join(placeholders, ",")), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cands []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.id, &c.path, &c.ext, &c.oldSHA, &c.oldSize); err != nil {
+			continue
+		}
+		cands = append(cands, c)
+	}
+	return cands, rows.Err()
+}
+
+// storeBody fingerprints one candidate, extracts its body if the fingerprint
+// changed, and writes both in a single transaction. Returns true if a body
+// was (re)extracted.
+func storeBody(conn *sql.DB, c candidate) bool {
+	sha, size, err := Fingerprint(c.path)
+	if err != nil {
+		return false
+	}
+	if sha == c.oldSHA && size == c.oldSize {
+		return false
+	}
+
+	extract, ok := Extractors[c.ext]
+	if !ok {
+		return false
+	}
+	body, err := extract(c.path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "content: extract %s: %v\n", c.path, err)
+		return false
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "content: begin %s: %v\n", c.path, err)
+		return false
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE files SET sha256 = ?, size = ? WHERE id = ?`, sha, size, c.id); err != nil {
+		fmt.Fprintf(os.Stderr, "content: update fingerprint %s: %v\n", c.path, err)
+		return false
+	}
+	if _, err := tx.Exec(`DELETE FROM files_fts WHERE path = ?`, c.path); err != nil {
+		fmt.Fprintf(os.Stderr, "content: clear fts row %s: %v\n", c.path, err)
+		return false
+	}
+	if _, err := tx.Exec(`INSERT INTO files_fts (path, body) VALUES (?, ?)`, c.path, body); err != nil {
+		fmt.Fprintf(os.Stderr, "content: insert fts row %s: %v\n", c.path, err)
+		return false
+	}
+	if err := tx.Commit(); err != nil {
+		fmt.Fprintf(os.Stderr, "content: commit %s: %v\n", c.path, err)
+		return false
+	}
+	return true
+}
@@ -3,6 +3,8 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"os"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -10,6 +12,14 @@ import (
 
 type DB struct {
 	Conn *sql.DB
+
+	// ContentSearchAvailable reports whether files_fts (and therefore
+	// --content indexing/search) is usable. It's false whenever the linked
+	// mattn/go-sqlite3 wasn't built with its `sqlite_fts5` build tag — e.g.
+	// `go build -tags sqlite_fts5 ./...` — which the FTS5 virtual table
+	// requires. Callers should check this before turning --content on
+	// rather than letting a `files_fts MATCH` query fail.
+	ContentSearchAvailable bool
 }
 
 func Open(path string) (*DB, error) {
@@ -44,18 +54,60 @@ func (d *DB) migrate() error {
 			mtime INTEGER,
 			size INTEGER,
 			is_dir INTEGER NOT NULL DEFAULT 0,
-			seen_gen INTEGER NOT NULL DEFAULT 0
+			seen_gen INTEGER NOT NULL DEFAULT 0,
+			dev INTEGER NOT NULL DEFAULT 0,
+			ino INTEGER NOT NULL DEFAULT 0
 		);`,
 		`CREATE INDEX IF NOT EXISTS idx_filename_norm ON files(filename_norm);`,
 		`CREATE INDEX IF NOT EXISTS idx_ext ON files(ext);`,
 		`CREATE INDEX IF NOT EXISTS idx_mtime ON files(mtime);`,
 		`CREATE INDEX IF NOT EXISTS idx_seen_gen ON files(seen_gen);`,
+		`CREATE INDEX IF NOT EXISTS idx_dev_ino ON files(dev, ino);`,
+		`CREATE TABLE IF NOT EXISTS file_trigrams (
+			trigram TEXT NOT NULL,
+			file_id INTEGER NOT NULL REFERENCES files(id) ON DELETE CASCADE
+		);`,
+		`CREATE INDEX IF NOT EXISTS idx_file_trigrams ON file_trigrams(trigram, file_id);`,
 	}
 	for _, s := range stmts {
 		if _, err := d.Conn.Exec(s); err != nil {
 			return err
 		}
 	}
+	// DBs created before identity tracking existed won't have dev/ino, and
+	// sha256 (the content fingerprint) is not in CREATE TABLE at all; add
+	// them in place rather than forcing a re-index.
+	for _, col := range []string{"dev INTEGER NOT NULL DEFAULT 0", "ino INTEGER NOT NULL DEFAULT 0", "sha256 TEXT"} {
+		if err := d.addColumnIfMissing("files", col); err != nil {
+			return err
+		}
+	}
+
+	// files_fts backs --content search. It's optional: the linked sqlite3
+	// needs FTS5 compiled in (mattn/go-sqlite3's `sqlite_fts5` build tag, e.g.
+	// `go build -tags sqlite_fts5 ./...`), so a missing module just disables
+	// content search rather than failing Open. ContentSearchAvailable records
+	// which happened so callers can gate --content on it instead of letting
+	// a files_fts query fail downstream.
+	if _, err := d.Conn.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS files_fts USING fts5(
+		path, body, tokenize = 'unicode61 remove_diacritics 2'
+	);`); err != nil {
+		fmt.Fprintf(os.Stderr, "findex: content search unavailable: %v (build with -tags sqlite_fts5 to enable it)\n", err)
+		d.ContentSearchAvailable = false
+	} else {
+		d.ContentSearchAvailable = true
+	}
+
+	return nil
+}
+
+// addColumnIfMissing runs an ALTER TABLE ADD COLUMN, tolerating the error
+// SQLite returns when the column is already there.
+func (d *DB) addColumnIfMissing(table, colDef string) error {
+	_, err := d.Conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, colDef))
+	if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 	return nil
 }
 
@@ -68,6 +120,9 @@ type FileRow struct {
 	Size         int64
 	IsDir        bool
 	SeenGen      int64
+	Dev          uint64
+	Ino          uint64
+	SHA256       string
 }
 
 func (d *DB) BeginTx() (*sql.Tx, error) {
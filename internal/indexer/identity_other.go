@@ -0,0 +1,10 @@
+//go:build !unix
+
+package indexer
+
+import "io/fs"
+
+// fileIdentity has no (dev,ino) source on non-Unix platforms.
+func fileIdentity(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package indexer
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileIdentity returns the (dev,ino) pair backing info, used to track a file
+// across renames and to dedupe hardlinks. ok is false if the platform's
+// fs.FileInfo.Sys() doesn't expose a *syscall.Stat_t.
+func fileIdentity(info fs.FileInfo) (dev, ino uint64, ok bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, false
+	}
+	return uint64(st.Dev), uint64(st.Ino), true
+}
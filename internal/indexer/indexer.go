@@ -2,23 +2,33 @@ package indexer
 
 import (
 	"database/sql"
-	"errors"
-	"fmt"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
-
-	"findex/internal/util"
 )
 
+// Progress reports a streaming Run's counters so a caller (the CLI) can
+// print a live counter instead of waiting silently for a multi-million-file
+// walk to finish.
+type Progress func(scanned, indexed int64)
+
 type Options struct {
-	Roots           []string
-	IncludeHidden   bool
-	FollowSymlinks  bool
-	IgnoreDirs      []string
-	OnlyExtensions  map[string]struct{} // optional filter: {"pdf":{}, "png":{}}
-	BatchSize       int
+	Roots          []string
+	IncludeHidden  bool
+	FollowSymlinks bool
+	IgnoreDirs     []string
+	OnlyExtensions map[string]struct{} // optional filter: {"pdf":{}, "png":{}}
+	BatchSize      int
+
+	// WorkDir holds the temp files Run's external sort spills to. Defaults
+	// to os.TempDir().
+	WorkDir string
+	// SortChunkRecords caps how many records are sorted in memory at once
+	// before being spilled as one run of the external merge sort. Defaults
+	// to defaultSortChunkRecords.
+	SortChunkRecords int
+	// Progress, if set, is called periodically during Run.
+	Progress Progress
 }
 
 func DefaultIgnoreDirs() []string {
@@ -42,110 +52,22 @@ func New(opts Options) *Indexer {
 	return &Indexer{opts: opts}
 }
 
-func (ix *Indexer) Run(tx *sql.Tx, gen int64) (int64, error) {
-	if len(ix.opts.Roots) == 0 {
-		return 0, errors.New("no roots provided")
-	}
-
-	upsertStmt, err := tx.Prepare(`
-		INSERT INTO files (path, filename, filename_norm, ext, mtime, size, is_dir, seen_gen)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		ON CONFLICT(path) DO UPDATE SET
-		  filename=excluded.filename,
-		  filename_norm=excluded.filename_norm,
-		  ext=excluded.ext,
-		  mtime=excluded.mtime,
-		  size=excluded.size,
-		  is_dir=excluded.is_dir,
-		  seen_gen=excluded.seen_gen
-	`)
-	if err != nil {
-		return 0, err
-	}
-	defer upsertStmt.Close()
-
-	var indexed int64 = 0
-
-	ignoreSet := make(map[string]struct{}, len(ix.opts.IgnoreDirs))
-	for _, d := range ix.opts.IgnoreDirs {
-		ignoreSet[d] = struct{}{}
-	}
-
-	walkFn := func(path string, d fs.DirEntry, walkErr error) error {
-		if walkErr != nil {
-			// Permission issues are common; keep going
-			return nil
-		}
-
-		name := d.Name()
-
-		// Skip ignored dirs early
-		if d.IsDir() {
-			if _, ok := ignoreSet[name]; ok {
-				return fs.SkipDir
-			}
-			// Hidden dirs (optional)
-			if !ix.opts.IncludeHidden && strings.HasPrefix(name, ".") && path != "." {
-				return fs.SkipDir
-			}
-			return nil
-		}
-
-		// Hidden files (optional)
-		if !ix.opts.IncludeHidden && strings.HasPrefix(name, ".") {
-			return nil
-		}
-
-		// Extension filter (optional)
-		ext := util.ExtLower(name)
-		if ix.opts.OnlyExtensions != nil && len(ix.opts.OnlyExtensions) > 0 {
-			if _, ok := ix.opts.OnlyExtensions[ext]; !ok {
-				return nil
-			}
-		}
-
-		info, err := d.Info()
-		if err != nil {
-			return nil
-		}
-
-		// Symlink policy
-		if info.Mode()&os.ModeSymlink != 0 && !ix.opts.FollowSymlinks {
-			return nil
-		}
-
-		norm := util.Normalize(name)
-
-		mtime := info.ModTime().Unix()
-		size := info.Size()
-
-		_, err = upsertStmt.Exec(path, name, norm, ext, mtime, size, 0, gen)
-		if err != nil {
-			return nil
-		}
-		indexed++
-		return nil
-	}
-
-	for _, root := range ix.opts.Roots {
-		root = expandHome(root)
-		root = filepath.Clean(root)
-
-		// Ensure root exists
-		if _, err := os.Stat(root); err != nil {
-			fmt.Fprintf(os.Stderr, "skip root %s: %v\n", root, err)
-			continue
-		}
-
-		_ = filepath.WalkDir(root, walkFn)
-	}
-
-	// Sweep anything not seen in this generation
-	if _, err := tx.Exec(`DELETE FROM files WHERE seen_gen <> ?`, gen); err != nil {
-		return indexed, err
-	}
+// queryRower is the common subset of *sql.DB and *sql.Tx ftsAvailable needs,
+// so both Run's top-level connection and sync's already-open transaction
+// can use the same check.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
 
-	return indexed, nil
+// ftsAvailable reports whether files_fts exists. It only does when the
+// linked mattn/go-sqlite3 was built with its sqlite_fts5 build tag (see
+// db.DB.ContentSearchAvailable); renaming a file whose body content.IndexBodies
+// indexed needs to follow its files_fts row along, but only if that row
+// could exist in the first place.
+func ftsAvailable(q queryRower) bool {
+	var name string
+	err := q.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'files_fts'`).Scan(&name)
+	return err == nil
 }
 
 func expandHome(p string) string {
@@ -0,0 +1,765 @@
+package indexer
+
+import (
+	"bufio"
+	"container/heap"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"findex/internal/util"
+)
+
+// Run indexes ix.opts.Roots without holding the whole file list in memory
+// or the whole sweep in one giant transaction. Holding a single tx open for
+// a multi-million-file walk balloons WAL size, and a straight upsert-then-
+// `DELETE FROM files WHERE seen_gen <> ?` turns the final sweep into a
+// full-table scan. Instead:
+//
+//  1. walk the tree into a temp file of length-prefixed records (walkToTemp) —
+//     the same trivial length-prefixed streaming format glocate uses
+//  2. sort that file by path with a bounded-memory external merge sort
+//     (externalSort)
+//  3. linear merge-join the sorted walk stream against
+//     `SELECT path FROM files ORDER BY path`, emitting inserts/updates/
+//     deletes in path order, batched into transactions of BatchSize rows
+//
+// The DB is only touched in step 3, after the sort has already produced a
+// stable, complete file on disk — so interrupting the walk or the sort is
+// always safe to retry; nothing has been written to the index yet.
+const defaultSortChunkRecords = 200_000
+
+func (ix *Indexer) Run(conn *sql.DB) (int64, error) {
+	if len(ix.opts.Roots) == 0 {
+		return 0, errors.New("no roots provided")
+	}
+
+	workDir := ix.opts.WorkDir
+	if workDir == "" {
+		workDir = os.TempDir()
+	}
+
+	rawFile, err := os.CreateTemp(workDir, "findex-walk-*.raw")
+	if err != nil {
+		return 0, err
+	}
+	rawPath := rawFile.Name()
+	defer os.Remove(rawPath)
+
+	bw := bufio.NewWriterSize(rawFile, 1<<20)
+	scanned, walkErr := ix.walkToTemp(bw)
+	if flushErr := bw.Flush(); walkErr == nil {
+		walkErr = flushErr
+	}
+	if closeErr := rawFile.Close(); walkErr == nil {
+		walkErr = closeErr
+	}
+	if walkErr != nil {
+		return 0, walkErr
+	}
+
+	sortedPath := rawPath + ".sorted"
+	if err := externalSort(rawPath, sortedPath, ix.opts.SortChunkRecords); err != nil {
+		return 0, err
+	}
+	defer os.Remove(sortedPath)
+
+	sortedFile, err := os.Open(sortedPath)
+	if err != nil {
+		return 0, err
+	}
+	defer sortedFile.Close()
+
+	dbPaths, err := loadDBPaths(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	// A rename can only be sourced from a path that's actually disappearing
+	// this pass — computed here, before the sorted walk file is read again
+	// for the merge-join proper, as a set of every current dbPaths entry
+	// the walk doesn't also mention.
+	deletedPaths, err := computeDeletedPaths(dbPaths, sortedPath)
+	if err != nil {
+		return 0, err
+	}
+
+	identity, err := loadIdentityIndex(conn, deletedPaths)
+	if err != nil {
+		return 0, err
+	}
+
+	return ix.mergeDiff(conn, bufio.NewReaderSize(sortedFile, 1<<20), identity, dbPaths, scanned)
+}
+
+// record is one file's identity + metadata as written to the on-disk walk
+// stream: a length-prefixed path followed by fixed-width mtime/size/dev/ino.
+// Streaming records through a temp file rather than holding them in a slice
+// is what keeps a multi-million-file walk's memory bounded.
+type record struct {
+	path  string
+	mtime int64
+	size  int64
+	dev   uint64
+	ino   uint64
+}
+
+func writeRecord(w io.Writer, r record) error {
+	if len(r.path) > 0xFFFF {
+		return fmt.Errorf("path too long to stream (%d bytes): %s", len(r.path), r.path)
+	}
+	var hdr [2]byte
+	binary.LittleEndian.PutUint16(hdr[:], uint16(len(r.path)))
+	if _, err := w.Write(hdr[:]); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, r.path); err != nil {
+		return err
+	}
+	var body [32]byte
+	binary.LittleEndian.PutUint64(body[0:8], uint64(r.mtime))
+	binary.LittleEndian.PutUint64(body[8:16], uint64(r.size))
+	binary.LittleEndian.PutUint64(body[16:24], r.dev)
+	binary.LittleEndian.PutUint64(body[24:32], r.ino)
+	_, err := w.Write(body[:])
+	return err
+}
+
+func readRecord(r io.Reader) (record, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return record{}, err // io.EOF here is the normal end-of-stream signal
+	}
+	pathBuf := make([]byte, binary.LittleEndian.Uint16(hdr[:]))
+	if _, err := io.ReadFull(r, pathBuf); err != nil {
+		return record{}, err
+	}
+	var body [32]byte
+	if _, err := io.ReadFull(r, body[:]); err != nil {
+		return record{}, err
+	}
+	return record{
+		path:  string(pathBuf),
+		mtime: int64(binary.LittleEndian.Uint64(body[0:8])),
+		size:  int64(binary.LittleEndian.Uint64(body[8:16])),
+		dev:   binary.LittleEndian.Uint64(body[16:24]),
+		ino:   binary.LittleEndian.Uint64(body[24:32]),
+	}, nil
+}
+
+// walkToTemp walks every root and appends one record per indexable file to
+// w, reporting scanned progress as it goes.
+func (ix *Indexer) walkToTemp(w io.Writer) (int64, error) {
+	var scanned int64
+
+	ignoreSet := make(map[string]struct{}, len(ix.opts.IgnoreDirs))
+	for _, d := range ix.opts.IgnoreDirs {
+		ignoreSet[d] = struct{}{}
+	}
+
+	walkFn := func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			// Permission issues are common; keep going
+			return nil
+		}
+
+		name := d.Name()
+
+		if d.IsDir() {
+			if _, ok := ignoreSet[name]; ok {
+				return fs.SkipDir
+			}
+			if !ix.opts.IncludeHidden && strings.HasPrefix(name, ".") && path != "." {
+				return fs.SkipDir
+			}
+			return nil
+		}
+
+		if !ix.opts.IncludeHidden && strings.HasPrefix(name, ".") {
+			return nil
+		}
+
+		ext := util.ExtLower(name)
+		if len(ix.opts.OnlyExtensions) > 0 {
+			if _, ok := ix.opts.OnlyExtensions[ext]; !ok {
+				return nil
+			}
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+
+		if info.Mode()&os.ModeSymlink != 0 && !ix.opts.FollowSymlinks {
+			return nil
+		}
+
+		dev, ino, _ := fileIdentity(info)
+		if err := writeRecord(w, record{
+			path: path, mtime: info.ModTime().Unix(), size: info.Size(), dev: dev, ino: ino,
+		}); err != nil {
+			return err
+		}
+
+		scanned++
+		if ix.opts.Progress != nil && scanned%1000 == 0 {
+			ix.opts.Progress(scanned, 0)
+		}
+		return nil
+	}
+
+	for _, root := range ix.opts.Roots {
+		root = expandHome(root)
+		root = filepath.Clean(root)
+
+		if _, err := os.Stat(root); err != nil {
+			fmt.Fprintf(os.Stderr, "skip root %s: %v\n", root, err)
+			continue
+		}
+
+		_ = filepath.WalkDir(root, walkFn)
+	}
+
+	return scanned, nil
+}
+
+// externalSort reads raw records from srcPath, sorts them in path order
+// using bounded-memory runs of sortChunkRecords records each, and writes the
+// fully-sorted stream to dstPath: split into sorted runs, then k-way merge
+// the runs, exactly like a classic external merge sort.
+func externalSort(srcPath, dstPath string, sortChunkRecords int) error {
+	if sortChunkRecords <= 0 {
+		sortChunkRecords = defaultSortChunkRecords
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	r := bufio.NewReaderSize(src, 1<<20)
+
+	var runPaths []string
+	defer func() {
+		for _, p := range runPaths {
+			_ = os.Remove(p)
+		}
+	}()
+
+	chunk := make([]record, 0, sortChunkRecords)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		sort.Slice(chunk, func(i, j int) bool { return chunk[i].path < chunk[j].path })
+
+		runFile, err := os.CreateTemp(filepath.Dir(dstPath), "findex-run-*")
+		if err != nil {
+			return err
+		}
+		bw := bufio.NewWriterSize(runFile, 1<<20)
+		for _, rec := range chunk {
+			if err := writeRecord(bw, rec); err != nil {
+				runFile.Close()
+				return err
+			}
+		}
+		err = bw.Flush()
+		closeErr := runFile.Close()
+		if err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		runPaths = append(runPaths, runFile.Name())
+		chunk = chunk[:0]
+		return nil
+	}
+
+	for {
+		rec, err := readRecord(r)
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		chunk = append(chunk, rec)
+		if len(chunk) >= sortChunkRecords {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	return kWayMerge(runPaths, dstPath)
+}
+
+type mergeItem struct {
+	rec    record
+	reader *bufio.Reader
+	file   *os.File
+}
+
+type mergeHeap []*mergeItem
+
+func (h mergeHeap) Len() int           { return len(h) }
+func (h mergeHeap) Less(i, j int) bool { return h[i].rec.path < h[j].rec.path }
+func (h mergeHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *mergeHeap) Push(x any)        { *h = append(*h, x.(*mergeItem)) }
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// kWayMerge merges the already-sorted run files into one fully sorted
+// stream at dstPath, keeping only one record per run in memory at a time.
+func kWayMerge(runPaths []string, dstPath string) error {
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+	bw := bufio.NewWriterSize(dst, 1<<20)
+
+	var h mergeHeap
+	for _, p := range runPaths {
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		rr := bufio.NewReaderSize(f, 1<<16)
+		rec, err := readRecord(rr)
+		if err != nil {
+			if err == io.EOF {
+				continue
+			}
+			return err
+		}
+		h = append(h, &mergeItem{rec: rec, reader: rr, file: f})
+	}
+	heap.Init(&h)
+
+	for h.Len() > 0 {
+		top := heap.Pop(&h).(*mergeItem)
+		if err := writeRecord(bw, top.rec); err != nil {
+			return err
+		}
+		next, err := readRecord(top.reader)
+		switch {
+		case err == nil:
+			top.rec = next
+			heap.Push(&h, top)
+		case err != io.EOF:
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+type identityKey struct{ dev, ino uint64 }
+
+// computeDeletedPaths merge-joins dbPaths (already sorted by loadDBPaths)
+// against the sorted walk file a second time to find every dbPaths entry
+// the walk no longer mentions. loadIdentityIndex uses this to restrict
+// rename sources to paths that are genuinely gone, rather than paths that
+// are merely sharing a (dev,ino) with another still-present file (e.g. two
+// hardlinks to the same inode) — one more sequential pass over the
+// already-sorted walk file is cheap next to the cost of getting that wrong.
+func computeDeletedPaths(dbPaths []string, sortedPath string) (map[string]struct{}, error) {
+	f, err := os.Open(sortedPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, 1<<20)
+
+	var walkPath string
+	var hasWalk bool
+	advance := func() error {
+		rec, err := readRecord(r)
+		switch {
+		case err == nil:
+			walkPath, hasWalk = rec.path, true
+		case err == io.EOF:
+			hasWalk = false
+		default:
+			return err
+		}
+		return nil
+	}
+	if err := advance(); err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]struct{})
+	i := 0
+	for i < len(dbPaths) {
+		dbPath := dbPaths[i]
+		switch {
+		case hasWalk && walkPath < dbPath:
+			if err := advance(); err != nil {
+				return nil, err
+			}
+		case hasWalk && walkPath == dbPath:
+			i++
+			if err := advance(); err != nil {
+				return nil, err
+			}
+		default: // dbPath < walkPath, or the walk has run out
+			deleted[dbPath] = struct{}{}
+			i++
+		}
+	}
+	return deleted, nil
+}
+
+// loadIdentityIndex loads the (dev,ino)->[]path map for every already-
+// indexed file that deletedPaths confirms is actually gone this pass, so a
+// genuine rename can be detected and updated in place during the
+// merge-join below, instead of showing up as an unrelated delete+insert
+// pair. Keeping every old path per identity (not just the last one seen)
+// matters for hardlinked files: if two deleted paths shared an inode, each
+// should pair off with a distinct newly-discovered path rather than both
+// racing to claim the same one. The map is bounded by how many files
+// disappeared this pass, not by the size of the walk (the full path list is
+// held separately by loadDBPaths).
+func loadIdentityIndex(conn *sql.DB, deletedPaths map[string]struct{}) (map[identityKey][]string, error) {
+	rows, err := conn.Query(`SELECT path, dev, ino FROM files WHERE dev != 0 OR ino != 0`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	idx := make(map[identityKey][]string)
+	for rows.Next() {
+		var path string
+		var dev, ino uint64
+		if err := rows.Scan(&path, &dev, &ino); err != nil {
+			return nil, err
+		}
+		if _, gone := deletedPaths[path]; !gone {
+			continue // still present in the walk: a hardlink, not a rename source
+		}
+		key := identityKey{dev, ino}
+		idx[key] = append(idx[key], path)
+	}
+	return idx, rows.Err()
+}
+
+const upsertSQL = `
+	INSERT INTO files (path, filename, filename_norm, ext, mtime, size, is_dir, seen_gen, dev, ino)
+	VALUES (?, ?, ?, ?, ?, ?, 0, 0, ?, ?)
+	ON CONFLICT(path) DO UPDATE SET
+	  filename=excluded.filename,
+	  filename_norm=excluded.filename_norm,
+	  ext=excluded.ext,
+	  mtime=excluded.mtime,
+	  size=excluded.size,
+	  dev=excluded.dev,
+	  ino=excluded.ino
+`
+
+const renameSQL = `
+	UPDATE files SET
+	  path=?, filename=?, filename_norm=?, ext=?, mtime=?, size=?, dev=?, ino=?
+	WHERE path = ?
+`
+
+// batchStmts bundles the statements one open transaction needs; mergeDiff
+// rotates to a fresh tx (and fresh batchStmts) every BatchSize rows.
+// ftsRename is nil when files_fts doesn't exist (see ftsAvailable) — there's
+// nothing to keep in sync in that case.
+type batchStmts struct {
+	upsert        *sql.Stmt
+	rename        *sql.Stmt
+	delete        *sql.Stmt
+	id            *sql.Stmt
+	trigramDelete *sql.Stmt
+	trigramInsert *sql.Stmt
+	ftsRename     *sql.Stmt
+}
+
+func beginBatch(conn *sql.DB, ftsOK bool) (*sql.Tx, *batchStmts, error) {
+	tx, err := conn.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	s := &batchStmts{}
+	preps := []struct {
+		dst **sql.Stmt
+		sql string
+	}{
+		{&s.upsert, upsertSQL},
+		{&s.rename, renameSQL},
+		{&s.delete, `DELETE FROM files WHERE path = ?`},
+		{&s.id, `SELECT id FROM files WHERE path = ?`},
+		{&s.trigramDelete, `DELETE FROM file_trigrams WHERE file_id = ?`},
+		{&s.trigramInsert, `INSERT INTO file_trigrams (trigram, file_id) VALUES (?, ?)`},
+	}
+	if ftsOK {
+		preps = append(preps, struct {
+			dst **sql.Stmt
+			sql string
+		}{&s.ftsRename, `UPDATE files_fts SET path = ? WHERE path = ?`})
+	}
+	for _, prep := range preps {
+		stmt, err := tx.Prepare(prep.sql)
+		if err != nil {
+			s.close()
+			_ = tx.Rollback()
+			return nil, nil, err
+		}
+		*prep.dst = stmt
+	}
+	return tx, s, nil
+}
+
+func (s *batchStmts) close() {
+	for _, stmt := range []*sql.Stmt{s.upsert, s.rename, s.delete, s.id, s.trigramDelete, s.trigramInsert, s.ftsRename} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+func refreshTrigrams(s *batchStmts, path, norm string) error {
+	var fileID int64
+	if err := s.id.QueryRow(path).Scan(&fileID); err != nil {
+		return err
+	}
+	if _, err := s.trigramDelete.Exec(fileID); err != nil {
+		return err
+	}
+	for tri := range util.Trigrams(norm) {
+		if _, err := s.trigramInsert.Exec(tri, fileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upsertRecord writes one walk record to files. If its (dev,ino) matches a
+// path identity knows is actually gone this pass, that's a rename: the
+// existing row is updated in place and its old path is marked renamedAway
+// so the merge-join below doesn't also report it as deleted. Each identity
+// key's old paths are consumed one at a time (identity[key] = identity[key][1:])
+// so two files that happen to share an inode pair off with distinct old
+// paths instead of both claiming the same one. A rename also carries along
+// the file's files_fts row (when one could exist) — otherwise a renamed
+// file's indexed body becomes unreachable under its new path forever, since
+// content.IndexBodies only re-extracts when the fingerprint changes.
+func upsertRecord(s *batchStmts, identity map[identityKey][]string, renamedAway map[string]struct{}, rec record) error {
+	name := filepath.Base(rec.path)
+	norm := util.Normalize(name)
+	ext := util.ExtLower(name)
+
+	if rec.dev != 0 || rec.ino != 0 {
+		key := identityKey{rec.dev, rec.ino}
+		if oldPaths := identity[key]; len(oldPaths) > 0 {
+			oldPath := oldPaths[0]
+			identity[key] = oldPaths[1:]
+			if _, err := s.rename.Exec(rec.path, name, norm, ext, rec.mtime, rec.size, rec.dev, rec.ino, oldPath); err != nil {
+				return err
+			}
+			if s.ftsRename != nil {
+				if _, err := s.ftsRename.Exec(rec.path, oldPath); err != nil {
+					return err
+				}
+			}
+			renamedAway[oldPath] = struct{}{}
+			return refreshTrigrams(s, rec.path, norm)
+		}
+	}
+
+	if _, err := s.upsert.Exec(rec.path, name, norm, ext, rec.mtime, rec.size, rec.dev, rec.ino); err != nil {
+		return err
+	}
+	return refreshTrigrams(s, rec.path, norm)
+}
+
+// loadDBPaths reads every indexed file path, in order, into memory up
+// front. mergeDiff needs the whole list to drive its merge-join, and the
+// underlying *sql.DB is opened with SetMaxOpenConns(1) (see db.Open): a
+// query left open across the write transactions beginBatch starts would
+// pin the one pooled connection and deadlock the first Begin() against
+// it. Reading the rows out and closing the query before touching the
+// database again keeps the single connection free for the batches.
+func loadDBPaths(conn *sql.DB) ([]string, error) {
+	rows, err := conn.Query(`SELECT path FROM files WHERE is_dir = 0 ORDER BY path`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var paths []string
+	for rows.Next() {
+		var path string
+		if err := rows.Scan(&path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, rows.Err()
+}
+
+// mergeDiff walks the sorted record stream and dbPaths (the already-indexed
+// paths, loaded in full by loadDBPaths) together in lockstep, the classic
+// sorted merge-join: a walk-only path is new (or a rename target), a
+// DB-only path is deleted, and a path on both sides just gets its metadata
+// refreshed. Work is batched into transactions of BatchSize rows so no
+// single transaction holds the whole tree's worth of changes.
+func (ix *Indexer) mergeDiff(conn *sql.DB, walk *bufio.Reader, identity map[identityKey][]string, dbPaths []string, scanned int64) (int64, error) {
+	var dbPath string
+	dbIdx := 0
+	hasDBRow := dbIdx < len(dbPaths)
+	if hasDBRow {
+		dbPath = dbPaths[dbIdx]
+	}
+	advanceDB := func() error {
+		dbIdx++
+		hasDBRow = dbIdx < len(dbPaths)
+		if hasDBRow {
+			dbPath = dbPaths[dbIdx]
+		}
+		return nil
+	}
+
+	var walkRec record
+	var hasWalkRec bool
+	advanceWalk := func() error {
+		rec, err := readRecord(walk)
+		switch {
+		case err == nil:
+			walkRec, hasWalkRec = rec, true
+		case err == io.EOF:
+			hasWalkRec = false
+		default:
+			return err
+		}
+		return nil
+	}
+	if err := advanceWalk(); err != nil {
+		return 0, err
+	}
+
+	batchSize := ix.opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	ftsOK := ftsAvailable(conn)
+	tx, stmts, err := beginBatch(conn, ftsOK)
+	if err != nil {
+		return 0, err
+	}
+	ops := 0
+	rotate := func() error {
+		ops++
+		if ops < batchSize {
+			return nil
+		}
+		stmts.close()
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		tx, stmts, err = beginBatch(conn, ftsOK)
+		ops = 0
+		return err
+	}
+
+	renamedAway := make(map[string]struct{})
+	var indexed int64
+
+	// Rename sources are deleted after the loop, not when the merge-join
+	// reaches them, so one that sorts before its new path is still there.
+	renameSources := make(map[string]struct{})
+	for _, paths := range identity {
+		for _, p := range paths {
+			renameSources[p] = struct{}{}
+		}
+	}
+
+	for hasWalkRec || hasDBRow {
+		switch {
+		case hasWalkRec && (!hasDBRow || walkRec.path < dbPath):
+			if err := upsertRecord(stmts, identity, renamedAway, walkRec); err != nil {
+				return indexed, err
+			}
+			indexed++
+			if err := rotate(); err != nil {
+				return indexed, err
+			}
+			if err := advanceWalk(); err != nil {
+				return indexed, err
+			}
+
+		case hasDBRow && (!hasWalkRec || dbPath < walkRec.path):
+			if _, source := renameSources[dbPath]; !source {
+				if _, err := stmts.delete.Exec(dbPath); err != nil {
+					return indexed, err
+				}
+				if err := rotate(); err != nil {
+					return indexed, err
+				}
+			}
+			if err := advanceDB(); err != nil {
+				return indexed, err
+			}
+
+		default: // same path on both sides: refresh metadata in place
+			if err := upsertRecord(stmts, nil, renamedAway, walkRec); err != nil {
+				return indexed, err
+			}
+			indexed++
+			if err := rotate(); err != nil {
+				return indexed, err
+			}
+			if err := advanceWalk(); err != nil {
+				return indexed, err
+			}
+			if err := advanceDB(); err != nil {
+				return indexed, err
+			}
+		}
+
+		if ix.opts.Progress != nil && indexed%1000 == 0 {
+			ix.opts.Progress(scanned, indexed)
+		}
+	}
+
+	for p := range renameSources {
+		if _, renamed := renamedAway[p]; !renamed {
+			if _, err := stmts.delete.Exec(p); err != nil {
+				return indexed, err
+			}
+		}
+	}
+
+	stmts.close()
+	if err := tx.Commit(); err != nil {
+		return indexed, err
+	}
+	if ix.opts.Progress != nil {
+		ix.opts.Progress(scanned, indexed)
+	}
+	return indexed, nil
+}
@@ -0,0 +1,117 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"findex/internal/db"
+)
+
+// TestRunTwiceAgainstPopulatedDB guards against a deadlock where mergeDiff
+// held its `SELECT path FROM files` query open across beginBatch's
+// conn.Begin(): with db.Open's SetMaxOpenConns(1), that pinned the only
+// pooled connection and Begin() blocked forever. The first Run against an
+// empty DB doesn't exercise it (the SELECT returns zero rows and closes
+// itself before any transaction is opened); the second Run, against a DB
+// that already has rows, does.
+func TestRunTwiceAgainstPopulatedDB(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	database, err := db.Open(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ix := New(Options{Roots: []string{dir}, WorkDir: dir})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ix.Run(database.Conn)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("first Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("first Run did not return")
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "b.txt"), []byte("world"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		_, err := ix.Run(database.Conn)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second Run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("second Run against a populated DB deadlocked")
+	}
+}
+
+// TestRunTwiceHardlinksBothSurvive guards against identity being keyed by a
+// single path per (dev,ino): re-running Run against a tree of untouched
+// hardlinks must not mistake one sibling for the other's rename source and
+// merge their rows together.
+func TestRunTwiceHardlinksBothSurvive(t *testing.T) {
+	dir := t.TempDir()
+	// The indexed root is kept apart from dir, where the db and Run's temp
+	// files live, so only the two hardlinks are walked.
+	root := filepath.Join(dir, "root")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	aPath := filepath.Join(root, "a.txt")
+	bPath := filepath.Join(root, "b.txt")
+	if err := os.WriteFile(aPath, []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Link(aPath, bPath); err != nil {
+		t.Skipf("hardlinks unsupported here: %v", err)
+	}
+
+	database, err := db.Open(filepath.Join(dir, "index.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	ix := New(Options{Roots: []string{root}, WorkDir: dir})
+
+	if _, err := ix.Run(database.Conn); err != nil {
+		t.Fatalf("first Run: %v", err)
+	}
+	if _, err := ix.Run(database.Conn); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+
+	rows, err := database.Conn.Query(`SELECT path FROM files WHERE is_dir = 0 ORDER BY path`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var paths []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, p)
+	}
+	if len(paths) != 2 || paths[0] != aPath || paths[1] != bPath {
+		t.Fatalf("expected both hardlinked paths to survive, got %v", paths)
+	}
+}
@@ -0,0 +1,225 @@
+package indexer
+
+import (
+	"bufio"
+	"database/sql"
+	"io"
+	"os"
+	"strings"
+
+	"findex/internal/util"
+)
+
+// SyncOp identifies the kind of change a snapshot-diff record describes.
+type SyncOp byte
+
+const (
+	SyncModified SyncOp = 'M'
+	SyncAdded    SyncOp = '+'
+	SyncRemoved  SyncOp = '-'
+	SyncRenamed  SyncOp = 'R'
+)
+
+// SyncRecord is one line of a parsed snapshot diff.
+type SyncRecord struct {
+	Op      SyncOp
+	Path    string
+	OldPath string // only set when Op == SyncRenamed
+}
+
+// ParseZFSDiff parses the machine-friendly output of `zfs diff -H old new`:
+// tab-separated lines of the form "<op>\t<path>" or, for renames,
+// "R\t<oldpath>\t<newpath>".
+func ParseZFSDiff(r io.Reader) ([]SyncRecord, error) {
+	var out []SyncRecord
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || len(fields[0]) == 0 {
+			continue
+		}
+		rec := SyncRecord{Op: SyncOp(fields[0][0])}
+		if rec.Op == SyncRenamed {
+			if len(fields) < 3 {
+				continue
+			}
+			rec.OldPath = fields[1]
+			rec.Path = fields[2]
+		} else {
+			rec.Path = fields[1]
+		}
+		out = append(out, rec)
+	}
+	return out, sc.Err()
+}
+
+// ParseBTRFSDiff parses the output of `btrfs subvolume find-new <subvol> <gen>`:
+// one line per changed inode ending in "... path <path>", plus a trailing
+// "transid marker was <gen>" line that we ignore. find-new can't tell a
+// rename from a modification, so every record comes back as SyncModified;
+// SyncFromDiff's targeted stat sorts out adds vs. updates when it runs.
+func ParseBTRFSDiff(r io.Reader) ([]SyncRecord, error) {
+	var out []SyncRecord
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" || strings.HasPrefix(line, "transid marker") {
+			continue
+		}
+		idx := strings.LastIndex(line, "path ")
+		if idx == -1 {
+			continue
+		}
+		out = append(out, SyncRecord{Op: SyncModified, Path: line[idx+len("path "):]})
+	}
+	return out, sc.Err()
+}
+
+// syncStmts bundles the prepared statements SyncFromDiff needs, including
+// the same (dev,ino) identity and file_trigrams upkeep stream.go's
+// upsertRecord/refreshTrigrams do for a full Run — otherwise a file synced
+// in this path would have no trigram rows and be unsearchable (search.Search
+// shortlists via file_trigrams for any query of 3+ chars) and no real
+// identity to recognize a later rename by. ftsRename is nil when files_fts
+// doesn't exist (see ftsAvailable).
+type syncStmts struct {
+	upsert        *sql.Stmt
+	delete        *sql.Stmt
+	rename        *sql.Stmt
+	id            *sql.Stmt
+	trigramDelete *sql.Stmt
+	trigramInsert *sql.Stmt
+	ftsRename     *sql.Stmt
+}
+
+func prepareSyncStmts(tx *sql.Tx) (*syncStmts, error) {
+	s := &syncStmts{}
+	preps := []struct {
+		dst **sql.Stmt
+		sql string
+	}{
+		{&s.upsert, `
+			INSERT INTO files (path, filename, filename_norm, ext, mtime, size, is_dir, seen_gen, dev, ino)
+			VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, ?)
+			ON CONFLICT(path) DO UPDATE SET
+			  filename=excluded.filename,
+			  filename_norm=excluded.filename_norm,
+			  ext=excluded.ext,
+			  mtime=excluded.mtime,
+			  size=excluded.size,
+			  is_dir=excluded.is_dir,
+			  dev=excluded.dev,
+			  ino=excluded.ino
+		`},
+		{&s.delete, `DELETE FROM files WHERE path = ?`},
+		{&s.rename, `UPDATE files SET path = ? WHERE path = ?`},
+		{&s.id, `SELECT id FROM files WHERE path = ?`},
+		{&s.trigramDelete, `DELETE FROM file_trigrams WHERE file_id = ?`},
+		{&s.trigramInsert, `INSERT INTO file_trigrams (trigram, file_id) VALUES (?, ?)`},
+	}
+	if ftsAvailable(tx) {
+		preps = append(preps, struct {
+			dst **sql.Stmt
+			sql string
+		}{&s.ftsRename, `UPDATE files_fts SET path = ? WHERE path = ?`})
+	}
+	for _, prep := range preps {
+		stmt, err := tx.Prepare(prep.sql)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+		*prep.dst = stmt
+	}
+	return s, nil
+}
+
+func (s *syncStmts) close() {
+	for _, stmt := range []*sql.Stmt{s.upsert, s.delete, s.rename, s.id, s.trigramDelete, s.trigramInsert, s.ftsRename} {
+		if stmt != nil {
+			stmt.Close()
+		}
+	}
+}
+
+// SyncFromDiff applies a parsed snapshot diff to the files table without
+// walking the tree: it stats only the paths the diff mentions to refresh
+// mtime/size/filename_norm/identity/trigrams, and turns deletes/renames
+// into targeted SQL instead of a full re-index.
+func SyncFromDiff(tx *sql.Tx, records []SyncRecord) (int64, error) {
+	s, err := prepareSyncStmts(tx)
+	if err != nil {
+		return 0, err
+	}
+	defer s.close()
+
+	var applied int64
+	for _, rec := range records {
+		switch rec.Op {
+		case SyncRemoved:
+			if _, err := s.delete.Exec(rec.Path); err != nil {
+				return applied, err
+			}
+		case SyncRenamed:
+			if _, err := s.rename.Exec(rec.Path, rec.OldPath); err != nil {
+				return applied, err
+			}
+			if s.ftsRename != nil {
+				if _, err := s.ftsRename.Exec(rec.Path, rec.OldPath); err != nil {
+					return applied, err
+				}
+			}
+			if err := statAndUpsert(s, rec.Path); err != nil {
+				return applied, err
+			}
+		default: // SyncModified, SyncAdded
+			if err := statAndUpsert(s, rec.Path); err != nil {
+				return applied, err
+			}
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// statAndUpsert stats a single affected path and writes its current
+// mtime/size/normalized filename/identity, then refreshes its trigram rows,
+// falling back to a no-op if the path has already vanished again by the
+// time we get to it.
+func statAndUpsert(s *syncStmts, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil
+	}
+	name := info.Name()
+	isDir := 0
+	if info.IsDir() {
+		isDir = 1
+	}
+	dev, ino, _ := fileIdentity(info)
+	norm := util.Normalize(name)
+	if _, err := s.upsert.Exec(path, name, norm, util.ExtLower(name), info.ModTime().Unix(), info.Size(), isDir, dev, ino); err != nil {
+		return err
+	}
+	if isDir == 1 {
+		return nil
+	}
+
+	var fileID int64
+	if err := s.id.QueryRow(path).Scan(&fileID); err != nil {
+		return err
+	}
+	if _, err := s.trigramDelete.Exec(fileID); err != nil {
+		return err
+	}
+	for tri := range util.Trigrams(norm) {
+		if _, err := s.trigramInsert.Exec(tri, fileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
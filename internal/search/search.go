@@ -1,8 +1,10 @@
 package search
 
 import (
+	"bufio"
 	"database/sql"
 	"fmt"
+	"io"
 	"math"
 	"sort"
 	"strings"
@@ -15,15 +17,19 @@ type QueryOptions struct {
 	Limit      int
 	ExtFilter  map[string]struct{} // e.g. {"pdf":{}}
 	Shortlist  int                 // how many candidates to pull from DB
+	SearchBody bool                // also match file contents via the files_fts index
 }
 
 type Result struct {
-	Path     string
-	Filename string
-	Ext      string
-	Mtime    int64
-	Size     int64
-	Score    float64
+	Path      string
+	Filename  string
+	Ext       string
+	Mtime     int64
+	Size      int64
+	Score     float64
+	Dev       uint64
+	Ino       uint64
+	LinkCount int // number of shortlisted hardlinks this result represents
 }
 
 func DefaultQueryOptions() QueryOptions {
@@ -48,60 +54,34 @@ func Search(db *sql.DB, q string, opts QueryOptions) ([]Result, error) {
 		return nil, nil
 	}
 
-	// Build SQL to shortlist candidates.
-	// Strategy: require that filename_norm matches at least one token (or the whole query)
-	likeParts := make([]string, 0, len(qTokens)+1)
-	args := make([]any, 0, len(qTokens)+2)
-
-	// whole query as substring
-	likeParts = append(likeParts, "filename_norm LIKE ?")
-	args = append(args, "%"+qNorm+"%")
-
-	for _, t := range qTokens {
-		likeParts = append(likeParts, "filename_norm LIKE ?")
-		args = append(args, "%"+t+"%")
+	// The trigram index can't help queries too short to have a single
+	// trigram, so fall back to the full LIKE scan for those.
+	var cands []Result
+	var err error
+	if len(strings.ReplaceAll(qNorm, " ", "")) >= 3 {
+		cands, err = shortlistByTrigram(db, qNorm, opts)
+	} else {
+		cands, err = shortlistByLike(db, qNorm, qTokens, opts)
 	}
-
-	where := "(" + strings.Join(likeParts, " OR ") + ")"
-
-	// Extension filter
-	if opts.ExtFilter != nil && len(opts.ExtFilter) > 0 {
-		exts := make([]string, 0, len(opts.ExtFilter))
-		for e := range opts.ExtFilter {
-			exts = append(exts, e)
-		}
-		sort.Strings(exts)
-		placeholders := make([]string, 0, len(exts))
-		for range exts {
-			placeholders = append(placeholders, "?")
-		}
-		where += " AND ext IN (" + strings.Join(placeholders, ",") + ")"
-		for _, e := range exts {
-			args = append(args, e)
-		}
-	}
-
-	sqlQ := fmt.Sprintf(`
-		SELECT path, filename, ext, mtime, size
-		FROM files
-		WHERE %s AND is_dir = 0
-		ORDER BY mtime DESC
-		LIMIT %d
-	`, where, opts.Shortlist)
-
-	rows, err := db.Query(sqlQ, args...)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	cands := make([]Result, 0, opts.Shortlist)
-	for rows.Next() {
-		var r Result
-		if err := rows.Scan(&r.Path, &r.Filename, &r.Ext, &r.Mtime, &r.Size); err != nil {
-			continue
+	// Content search: pull in anything matched by body, not just filename,
+	// and remember its normalized BM25 rank so the scoring loop below can
+	// fold it into each candidate's score. files_fts only exists when the
+	// linked sqlite3 was built with FTS5 (see db.DB.ContentSearchAvailable);
+	// checking once here lets --content degrade to filename-only results
+	// instead of failing the whole query against a missing table.
+	var bodyHits map[string]float64
+	if opts.SearchBody && ftsAvailable(db) {
+		bodyHits, err = searchBody(db, qTokens, opts.Shortlist)
+		if err != nil {
+			return nil, err
+		}
+		if extra, err := fetchMissingByPath(db, cands, bodyHits); err == nil {
+			cands = append(cands, extra...)
 		}
-		cands = append(cands, r)
 	}
 
 	// Rank
@@ -141,9 +121,18 @@ func Search(db *sql.DB, q string, opts QueryOptions) ([]Result, error) {
 		// 6) tiny bonus for shorter filenames (often cleaner)
 		score += 0.15 * (1.0 / (1.0 + float64(len(fnNorm))/40.0))
 
+		// 7) body match (content search): filename score * 1.0 + normalized
+		// BM25 body score * 0.6, so `passport renewal` can surface a PDF
+		// whose name doesn't mention either word at all.
+		if bodyRank, ok := bodyHits[cands[i].Path]; ok {
+			score += 0.6 * bodyRank
+		}
+
 		cands[i].Score = score
 	}
 
+	cands = collapseHardlinks(cands)
+
 	sort.SliceStable(cands, func(i, j int) bool {
 	// Primary: latest modified
 	if cands[i].Mtime != cands[j].Mtime {
@@ -160,6 +149,308 @@ func Search(db *sql.DB, q string, opts QueryOptions) ([]Result, error) {
 	return cands, nil
 }
 
+// SearchStream runs Search and writes each ranked result to w as a
+// "path\tmtime" line, for callers with a socket (findex serve answering a
+// picker's live-reload query) or any other line-oriented consumer. Search
+// runs to completion first: the whole []Result is built and ranked before
+// the first line is written.
+func SearchStream(db *sql.DB, q string, opts QueryOptions, w io.Writer) error {
+	res, err := Search(db, q, opts)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, r := range res {
+		fmt.Fprintf(bw, "%s\t%d\n", r.Path, r.Mtime)
+	}
+	return bw.Flush()
+}
+
+// collapseHardlinks merges candidates that share a (dev,ino) identity into a
+// single result, keeping the highest-scoring path as the representative and
+// recording how many of the candidates it stands in for; hardlinks that
+// were not shortlisted are not counted. Rows without a
+// known identity (dev==0 && ino==0, e.g. non-Unix platforms) pass through
+// untouched.
+func collapseHardlinks(cands []Result) []Result {
+	type key struct{ dev, ino uint64 }
+
+	best := make(map[key]int)
+	order := make([]int, 0, len(cands))
+	for i := range cands {
+		if cands[i].Dev == 0 && cands[i].Ino == 0 {
+			order = append(order, i)
+			continue
+		}
+		k := key{cands[i].Dev, cands[i].Ino}
+		j, seen := best[k]
+		if !seen {
+			best[k] = i
+			order = append(order, i)
+			continue
+		}
+		if cands[i].Score > cands[j].Score {
+			best[k] = i
+		}
+	}
+
+	out := make([]Result, 0, len(order))
+	for _, i := range order {
+		r := cands[i]
+		if r.Dev != 0 || r.Ino != 0 {
+			r = cands[best[key{r.Dev, r.Ino}]]
+		}
+		out = append(out, r)
+	}
+
+	counts := make(map[key]int, len(out))
+	for i := range cands {
+		if cands[i].Dev == 0 && cands[i].Ino == 0 {
+			continue
+		}
+		counts[key{cands[i].Dev, cands[i].Ino}]++
+	}
+	for i := range out {
+		if out[i].Dev != 0 || out[i].Ino != 0 {
+			out[i].LinkCount = counts[key{out[i].Dev, out[i].Ino}]
+		}
+	}
+	return out
+}
+
+// ftsAvailable reports whether files_fts exists, so callers can skip body
+// search instead of running a MATCH query against a table that was never
+// created (sqlite3 built without the sqlite_fts5 tag).
+func ftsAvailable(db *sql.DB) bool {
+	var name string
+	err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = 'files_fts'`).Scan(&name)
+	return err == nil
+}
+
+// ftsMatchQuery renders tokens as an FTS5 MATCH expression that treats each
+// token as a literal string rather than FTS5 query syntax. Without this,
+// characters FTS5 gives special meaning (-, ", :, *, parens, ...) turn
+// ordinary queries like "re-invoice" or a date into a query-syntax error
+// instead of a plain body search.
+func ftsMatchQuery(tokens []string) string {
+	quoted := make([]string, 0, len(tokens))
+	for _, t := range tokens {
+		if t == "" {
+			continue
+		}
+		quoted = append(quoted, `"`+strings.ReplaceAll(t, `"`, `""`)+`"`)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// searchBody runs an FTS5 MATCH against files_fts and returns a map of path
+// to a 0..1 normalized score where 1 is the best match in this result set
+// (FTS5's bm25() is lower-is-better, so the normalization flips it).
+func searchBody(db *sql.DB, tokens []string, limit int) (map[string]float64, error) {
+	matchQ := ftsMatchQuery(tokens)
+	if matchQ == "" {
+		return nil, nil
+	}
+
+	rows, err := db.Query(`
+		SELECT f.path, bm25(files_fts) AS rank
+		FROM files_fts
+		JOIN files f ON f.path = files_fts.path
+		WHERE files_fts MATCH ? AND f.is_dir = 0
+		ORDER BY rank
+		LIMIT ?
+	`, matchQ, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type hit struct {
+		path string
+		rank float64
+	}
+	var hits []hit
+	minRank, maxRank := math.Inf(1), math.Inf(-1)
+	for rows.Next() {
+		var h hit
+		if err := rows.Scan(&h.path, &h.rank); err != nil {
+			continue
+		}
+		hits = append(hits, h)
+		if h.rank < minRank {
+			minRank = h.rank
+		}
+		if h.rank > maxRank {
+			maxRank = h.rank
+		}
+	}
+
+	out := make(map[string]float64, len(hits))
+	span := maxRank - minRank
+	for _, h := range hits {
+		norm := 1.0
+		if span > 0 {
+			norm = 1 - (h.rank-minRank)/span
+		}
+		out[h.path] = norm
+	}
+	return out, rows.Err()
+}
+
+// fetchMissingByPath loads metadata for any bodyHits path not already in
+// cands, so a file that matches only by content still gets a Result to
+// score and rank.
+func fetchMissingByPath(db *sql.DB, cands []Result, bodyHits map[string]float64) ([]Result, error) {
+	have := make(map[string]struct{}, len(cands))
+	for _, c := range cands {
+		have[c.Path] = struct{}{}
+	}
+
+	missing := make([]string, 0, len(bodyHits))
+	for p := range bodyHits {
+		if _, ok := have[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	if len(missing) == 0 {
+		return nil, nil
+	}
+
+	placeholders := make([]string, len(missing))
+	args := make([]any, len(missing))
+	for i, p := range missing {
+		placeholders[i] = "?"
+		args[i] = p
+	}
+
+	sqlQ := fmt.Sprintf(`
+		SELECT path, filename, ext, mtime, size, dev, ino
+		FROM files
+		WHERE path IN (%s) AND is_dir = 0
+	`, strings.Join(placeholders, ","))
+
+	return queryCandidates(db, sqlQ, args)
+}
+
+// shortlistByTrigram uses the file_trigrams inverted index to find
+// candidates by trigram overlap with qNorm, ordered by hit count, instead of
+// a per-row LIKE scan. This turns an arbitrary-substring query into a set of
+// index lookups and is what makes filename search sub-100ms on multi-million
+// file indexes.
+func shortlistByTrigram(db *sql.DB, qNorm string, opts QueryOptions) ([]Result, error) {
+	qTri := util.Trigrams(qNorm)
+	if len(qTri) == 0 {
+		return nil, nil
+	}
+	trigrams := make([]string, 0, len(qTri))
+	for t := range qTri {
+		trigrams = append(trigrams, t)
+	}
+
+	args := make([]any, 0, len(trigrams)+len(opts.ExtFilter)+1)
+	triPlaceholders := make([]string, len(trigrams))
+	for i, t := range trigrams {
+		triPlaceholders[i] = "?"
+		args = append(args, t)
+	}
+
+	extWhere, extArgs := extFilterClause(opts.ExtFilter, "f")
+	args = append(args, extArgs...)
+	args = append(args, opts.Shortlist)
+
+	sqlQ := fmt.Sprintf(`
+		SELECT f.path, f.filename, f.ext, f.mtime, f.size, f.dev, f.ino
+		FROM files f
+		JOIN (
+			SELECT file_id, COUNT(*) AS hits
+			FROM file_trigrams
+			WHERE trigram IN (%s)
+			GROUP BY file_id
+		) t ON t.file_id = f.id
+		WHERE f.is_dir = 0 %s
+		ORDER BY t.hits DESC, f.mtime DESC
+		LIMIT ?
+	`, strings.Join(triPlaceholders, ","), extWhere)
+
+	return queryCandidates(db, sqlQ, args)
+}
+
+// shortlistByLike is the pre-trigram full-scan shortlist, kept as a fallback
+// for queries too short to produce a useful trigram (fewer than 3 chars).
+func shortlistByLike(db *sql.DB, qNorm string, qTokens []string, opts QueryOptions) ([]Result, error) {
+	likeParts := make([]string, 0, len(qTokens)+1)
+	args := make([]any, 0, len(qTokens)+2)
+
+	// whole query as substring
+	likeParts = append(likeParts, "filename_norm LIKE ?")
+	args = append(args, "%"+qNorm+"%")
+
+	for _, t := range qTokens {
+		likeParts = append(likeParts, "filename_norm LIKE ?")
+		args = append(args, "%"+t+"%")
+	}
+
+	where := "(" + strings.Join(likeParts, " OR ") + ")"
+
+	extWhere, extArgs := extFilterClause(opts.ExtFilter, "")
+	where += extWhere
+	args = append(args, extArgs...)
+	args = append(args, opts.Shortlist)
+
+	sqlQ := fmt.Sprintf(`
+		SELECT path, filename, ext, mtime, size, dev, ino
+		FROM files
+		WHERE %s AND is_dir = 0
+		ORDER BY mtime DESC
+		LIMIT ?
+	`, where)
+
+	return queryCandidates(db, sqlQ, args)
+}
+
+func queryCandidates(db *sql.DB, sqlQ string, args []any) ([]Result, error) {
+	rows, err := db.Query(sqlQ, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cands []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.Path, &r.Filename, &r.Ext, &r.Mtime, &r.Size, &r.Dev, &r.Ino); err != nil {
+			continue
+		}
+		cands = append(cands, r)
+	}
+	return cands, rows.Err()
+}
+
+// extFilterClause renders an "AND ext IN (...)" clause (with tablePrefix.ext
+// if tablePrefix is non-empty) plus its bind args, or ("", nil) if extFilter
+// is empty.
+func extFilterClause(extFilter map[string]struct{}, tablePrefix string) (string, []any) {
+	if len(extFilter) == 0 {
+		return "", nil
+	}
+	col := "ext"
+	if tablePrefix != "" {
+		col = tablePrefix + ".ext"
+	}
+	exts := make([]string, 0, len(extFilter))
+	for e := range extFilter {
+		exts = append(exts, e)
+	}
+	sort.Strings(exts)
+	placeholders := make([]string, len(exts))
+	args := make([]any, len(exts))
+	for i, e := range exts {
+		placeholders[i] = "?"
+		args[i] = e
+	}
+	return fmt.Sprintf(" AND %s IN (%s)", col, strings.Join(placeholders, ",")), args
+}
+
 func tokenOverlapCount(a, b []string) int {
 	if len(a) == 0 || len(b) == 0 {
 		return 0
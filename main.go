@@ -2,9 +2,11 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
 	"io"
+	"net"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"findex/internal/content"
 	"findex/internal/db"
 	"findex/internal/indexer"
 	"findex/internal/search"
@@ -27,8 +30,12 @@ func main() {
 	switch cmd {
 	case "index":
 		runIndex(os.Args[2:])
+	case "sync":
+		runSync(os.Args[2:])
+	case "serve":
+		runServe(os.Args[2:])
 	case "q":
-		runQuery(os.Args[2:], false)
+		runQuery(os.Args[2:])
 	case "open":
 		runPick(os.Args[2:], false)
 	case "reveal":
@@ -43,14 +50,21 @@ func usage() {
 	fmt.Println(`findex â€” fast filename index + search
 
 Usage:
-  findex index  [--db <path>] [--root <path> ...] [--pdf] [--img]
-  findex q      [--db <path>] [--limit N] [--since 7d] <query>
-  findex open   [--db <path>] [--since 7d] <query>
-  findex reveal [--db <path>] [--since 7d] <query>
+  findex index  [--db <path>] [--root <path> ...] [--pdf] [--img] [--content] [--content-workers N]
+  findex sync   [--db <path>] --zfs pool/dataset@old..@new
+  findex sync   [--db <path>] --btrfs <old-subvol> <new-subvol>
+  findex serve  [--db <path>] [--socket <path>]
+  findex q      [--db <path>] [--limit N] [--since 7d] [--content] [--socket <path>] <query>
+  findex open   [--db <path>] [--since 7d] [--content] [--live] [--socket <path>] <query>
+  findex reveal [--db <path>] [--since 7d] [--content] [--live] [--socket <path>] <query>
 
 Examples:
   findex index --root ~ --pdf
+  findex index --root ~/Documents --content
+  findex sync --zfs tank/home@yesterday..@now
+  findex serve &
   findex q passport --since 7d
+  findex q "passport renewal" --content
   findex open invoice --since 24h
 `)
 }
@@ -68,11 +82,12 @@ func ensureDir(p string) error {
 
 /* ---------- shared flags ---------- */
 
-func parseCommon(fs *flag.FlagSet) (dbPath string, roots multiFlag, onlyPDF bool, onlyIMG bool) {
+func parseCommon(fs *flag.FlagSet) (dbPath string, roots multiFlag, onlyPDF bool, onlyIMG bool, searchBody bool) {
 	fs.StringVar(&dbPath, "db", defaultDBPath(), "path to sqlite db")
 	fs.Var(&roots, "root", "root to index (repeatable). default: ~")
 	fs.BoolVar(&onlyPDF, "pdf", false, "filter to PDFs (index/search)")
 	fs.BoolVar(&onlyIMG, "img", false, "filter to images (index/search)")
+	fs.BoolVar(&searchBody, "content", false, "index (findex index) or also search (findex q/open/reveal) file contents")
 	return
 }
 
@@ -80,9 +95,10 @@ func parseCommon(fs *flag.FlagSet) (dbPath string, roots multiFlag, onlyPDF bool
 
 func runIndex(args []string) {
 	fs := flag.NewFlagSet("index", flag.ExitOnError)
-	dbPath, roots, onlyPDF, onlyIMG := parseCommon(fs)
+	dbPath, roots, onlyPDF, onlyIMG, withContent := parseCommon(fs)
 	follow := fs.Bool("follow", false, "follow symlinks")
 	hidden := fs.Bool("hidden", false, "include hidden files")
+	contentWorkers := fs.Int("content-workers", 4, "concurrent content extractions (with --content)")
 	fs.Parse(args)
 
 	if err := ensureDir(dbPath); err != nil {
@@ -115,22 +131,83 @@ func runIndex(args []string) {
 		extMap = extFilter
 	}
 
-	tx, err := d.BeginTx()
-	if err != nil {
-		fatal(err)
-	}
-
-	gen := time.Now().Unix()
+	quiet := !isTTY(os.Stdout)
 	ix := indexer.New(indexer.Options{
 		Roots:          rootList,
 		IncludeHidden:  *hidden,
 		FollowSymlinks: *follow,
 		OnlyExtensions: extMap,
 		BatchSize:      1000,
+		Progress: func(scanned, indexed int64) {
+			if !quiet {
+				fmt.Fprintf(os.Stderr, "\rscanned %d, indexed %d...", scanned, indexed)
+			}
+		},
 	})
 
 	start := time.Now()
-	n, err := ix.Run(tx, gen)
+	n, err := ix.Run(d.Conn)
+	if err != nil {
+		fatal(err)
+	}
+	if !quiet {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	fmt.Printf("Indexed %d files in %s\n", n, time.Since(start).Round(time.Millisecond))
+
+	if withContent {
+		bodyStart := time.Now()
+		extracted, err := content.IndexBodies(d.Conn, *contentWorkers, nil)
+		if err != nil {
+			fatal(err)
+		}
+		fmt.Printf("Extracted %d file bodies in %s\n", extracted, time.Since(bodyStart).Round(time.Millisecond))
+	}
+}
+
+/* ---------- sync ---------- */
+
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDBPath(), "path to sqlite db")
+	zfsSpec := fs.String("zfs", "", "zfs snapshot pair, e.g. pool/dataset@old..@new")
+	btrfs := fs.Bool("btrfs", false, "diff two btrfs subvolumes given as trailing args: old new")
+	fs.Parse(args)
+
+	if (*zfsSpec == "") == !*btrfs {
+		fatal(fmt.Errorf("specify exactly one of --zfs or --btrfs"))
+	}
+
+	var records []indexer.SyncRecord
+	var err error
+	switch {
+	case *zfsSpec != "":
+		records, err = diffZFS(*zfsSpec)
+	case *btrfs:
+		rest := fs.Args()
+		if len(rest) != 2 {
+			fatal(fmt.Errorf("--btrfs requires two trailing args: old-subvol new-subvol"))
+		}
+		records, err = diffBTRFS(rest[0], rest[1])
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	d, err := db.Open(*dbPath)
+	if err != nil {
+		fatal(err)
+	}
+	defer d.Close()
+
+	tx, err := d.BeginTx()
+	if err != nil {
+		fatal(err)
+	}
+
+	start := time.Now()
+	n, err := indexer.SyncFromDiff(tx, records)
 	if err != nil {
 		_ = tx.Rollback()
 		fatal(err)
@@ -139,16 +216,194 @@ func runIndex(args []string) {
 		fatal(err)
 	}
 
-	fmt.Printf("Indexed %d files in %s\n", n, time.Since(start).Round(time.Millisecond))
+	fmt.Printf("Synced %d changes in %s\n", n, time.Since(start).Round(time.Millisecond))
+}
+
+// diffZFS turns "pool/dataset@old..@new" into a `zfs diff -H` invocation and
+// parses its output.
+func diffZFS(spec string) ([]indexer.SyncRecord, error) {
+	parts := strings.SplitN(spec, "..", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid --zfs spec %q (want pool/dataset@old..@new)", spec)
+	}
+	oldSnap := parts[0]
+	at := strings.Index(oldSnap, "@")
+	if at == -1 {
+		return nil, fmt.Errorf("invalid --zfs spec %q: missing dataset@old", spec)
+	}
+	newSnap := oldSnap[:at] + parts[1]
+
+	out, err := exec.Command("zfs", "diff", "-H", oldSnap, newSnap).Output()
+	if err != nil {
+		return nil, fmt.Errorf("zfs diff: %w", err)
+	}
+	return indexer.ParseZFSDiff(bytes.NewReader(out))
+}
+
+// diffBTRFS resolves the generation of oldSnap and asks `btrfs subvolume
+// find-new` for everything in newSnap newer than it.
+func diffBTRFS(oldSnap, newSnap string) ([]indexer.SyncRecord, error) {
+	showOut, err := exec.Command("btrfs", "subvolume", "show", oldSnap).Output()
+	if err != nil {
+		return nil, fmt.Errorf("btrfs subvolume show: %w", err)
+	}
+	gen, err := parseBTRFSGeneration(showOut)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command("btrfs", "subvolume", "find-new", newSnap, strconv.FormatInt(gen, 10)).Output()
+	if err != nil {
+		return nil, fmt.Errorf("btrfs subvolume find-new: %w", err)
+	}
+	return indexer.ParseBTRFSDiff(bytes.NewReader(out))
+}
+
+func parseBTRFSGeneration(out []byte) (int64, error) {
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "Generation:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		return strconv.ParseInt(fields[len(fields)-1], 10, 64)
+	}
+	return 0, fmt.Errorf("could not find Generation in btrfs subvolume show output")
+}
+
+/* ---------- serve ---------- */
+
+func defaultSocketPath() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".findex", "findex.sock")
+}
+
+// runServe keeps the sqlite db open behind a unix socket so a live-reloading
+// picker doesn't pay db.Open/migrate on every keystroke: each query becomes
+// a socket round-trip instead.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	dbPath := fs.String("db", defaultDBPath(), "path to sqlite db")
+	socketPath := fs.String("socket", defaultSocketPath(), "unix socket path to listen on")
+	fs.Parse(args)
+
+	d, err := db.Open(*dbPath)
+	if err != nil {
+		fatal(err)
+	}
+	defer d.Close()
+
+	if err := ensureDir(*socketPath); err != nil {
+		fatal(err)
+	}
+	_ = os.Remove(*socketPath) // clear a stale socket left by a previous run
+
+	ln, err := net.Listen("unix", *socketPath)
+	if err != nil {
+		fatal(err)
+	}
+	defer ln.Close()
+
+	fmt.Printf("findex serve: listening on %s (db %s)\n", *socketPath, *dbPath)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "accept:", err)
+			continue
+		}
+		go handleServeConn(d, conn)
+	}
+}
+
+// handleServeConn answers one request line of the form
+// "<limit>\t<ext1,ext2,...>\t<0|1 content>\t<query>\n" with "path\tmtime"
+// lines until EOF.
+func handleServeConn(d *db.DB, conn net.Conn) {
+	defer conn.Close()
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return
+	}
+
+	limit, extFilter, searchBody, q, err := parseServeRequest(line)
+	if err != nil {
+		fmt.Fprintln(conn, "Error:", err)
+		return
+	}
+
+	opts := search.DefaultQueryOptions()
+	opts.Limit = limit
+	opts.ExtFilter = extFilter
+	opts.SearchBody = searchBody
+
+	if err := search.SearchStream(d.Conn, q, opts, conn); err != nil {
+		fmt.Fprintln(conn, "Error:", err)
+	}
+}
+
+func parseServeRequest(line string) (limit int, extFilter map[string]struct{}, searchBody bool, q string, err error) {
+	line = strings.TrimRight(line, "\n")
+	parts := strings.SplitN(line, "\t", 4)
+	if len(parts) != 4 {
+		return 0, nil, false, "", fmt.Errorf("malformed request")
+	}
+	limit, err = strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, nil, false, "", fmt.Errorf("bad limit: %w", err)
+	}
+	if parts[1] != "" {
+		extFilter = make(map[string]struct{})
+		for _, e := range strings.Split(parts[1], ",") {
+			extFilter[e] = struct{}{}
+		}
+	}
+	searchBody = parts[2] == "1"
+	return limit, extFilter, searchBody, parts[3], nil
+}
+
+// queryViaSocket sends one request to a running `findex serve` and collects
+// its streamed "path\tmtime" response.
+func queryViaSocket(socketPath, q string, limit int, extFilter map[string]struct{}, searchBody bool) ([]search.Result, error) {
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("connect to findex serve: %w", err)
+	}
+	defer conn.Close()
+
+	exts := make([]string, 0, len(extFilter))
+	for e := range extFilter {
+		exts = append(exts, e)
+	}
+	contentFlag := "0"
+	if searchBody {
+		contentFlag = "1"
+	}
+	fmt.Fprintf(conn, "%d\t%s\t%s\t%s\n", limit, strings.Join(exts, ","), contentFlag, q)
+
+	var res []search.Result
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		mtime, _ := strconv.ParseInt(fields[1], 10, 64)
+		res = append(res, search.Result{Path: fields[0], Mtime: mtime})
+	}
+	return res, sc.Err()
 }
 
 /* ---------- query ---------- */
 
-func runQuery(args []string, quiet bool) {
+func runQuery(args []string) {
 	fs := flag.NewFlagSet("q", flag.ExitOnError)
-	dbPath, _, onlyPDF, onlyIMG := parseCommon(fs)
+	dbPath, _, onlyPDF, onlyIMG, searchBody := parseCommon(fs)
 	limit := fs.Int("limit", 30, "max results")
 	sinceFlag := fs.String("since", "", "time window like 24h, 7d, 2w")
+	quiet := fs.Bool("quiet", false, "print bare paths only, no score (for scripting / fzf reload)")
+	socketPath := fs.String("socket", "", "query a running `findex serve` over this unix socket instead of opening the db directly")
 	fs.Parse(args)
 
 	q := strings.Join(fs.Args(), " ")
@@ -156,24 +411,33 @@ func runQuery(args []string, quiet bool) {
 		fatal(fmt.Errorf("query required"))
 	}
 
-	d, err := db.Open(dbPath)
-	if err != nil {
-		fatal(err)
-	}
-	defer d.Close()
+	extFilter := buildExtFilter(onlyPDF, onlyIMG)
 
-	opts := search.DefaultQueryOptions()
-	opts.Limit = *limit
-	opts.ExtFilter = buildExtFilter(onlyPDF, onlyIMG)
+	var res []search.Result
+	var err error
+	if *socketPath != "" {
+		res, err = queryViaSocket(*socketPath, q, *limit, extFilter, searchBody)
+	} else {
+		var d *db.DB
+		d, err = db.Open(dbPath)
+		if err != nil {
+			fatal(err)
+		}
+		defer d.Close()
 
-	res, err := search.Search(d.Conn, q, opts)
+		opts := search.DefaultQueryOptions()
+		opts.Limit = *limit
+		opts.ExtFilter = extFilter
+		opts.SearchBody = searchBody
+		res, err = search.Search(d.Conn, q, opts)
+	}
 	if err != nil {
 		fatal(err)
 	}
 
 	res = applySinceFilter(res, sinceFlag)
 
-	if quiet {
+	if *quiet {
 		for _, r := range res {
 			fmt.Println(r.Path)
 		}
@@ -189,9 +453,11 @@ func runQuery(args []string, quiet bool) {
 
 func runPick(args []string, reveal bool) {
 	fs := flag.NewFlagSet("open", flag.ExitOnError)
-	dbPath, _, onlyPDF, onlyIMG := parseCommon(fs)
+	dbPath, _, onlyPDF, onlyIMG, searchBody := parseCommon(fs)
 	limit := fs.Int("limit", 80, "how many to pass to picker")
 	sinceFlag := fs.String("since", "", "time window like 24h, 7d, 2w")
+	socketPath := fs.String("socket", "", "use a running `findex serve` over this unix socket for live reload")
+	live := fs.Bool("live", isTTY(os.Stdout), "let fzf re-query the index on every keystroke instead of filtering one static shortlist")
 	fs.Parse(args)
 
 	q := strings.Join(fs.Args(), " ")
@@ -199,6 +465,13 @@ func runPick(args []string, reveal bool) {
 		fatal(fmt.Errorf("query required"))
 	}
 
+	if *live {
+		if err := pickWithFzfLive(dbPath, q, *limit, onlyPDF, onlyIMG, searchBody, *sinceFlag, *socketPath, reveal); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
 	d, err := db.Open(dbPath)
 	if err != nil {
 		fatal(err)
@@ -208,6 +481,7 @@ func runPick(args []string, reveal bool) {
 	opts := search.DefaultQueryOptions()
 	opts.Limit = *limit
 	opts.ExtFilter = buildExtFilter(onlyPDF, onlyIMG)
+	opts.SearchBody = searchBody
 
 	res, err := search.Search(d.Conn, q, opts)
 	if err != nil {
@@ -278,6 +552,90 @@ func pickWithFzf(res []search.Result) (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// pickWithFzfLive launches fzf with --disabled so it doesn't filter a static
+// list itself; every keystroke instead reloads the candidate list by
+// shelling back out to `findex q`, and Enter hands the chosen path straight
+// to `open`/`open -R` via fzf's own become() bind. This gives the real
+// incremental-filtering experience fzf is built for instead of one-shot
+// filtering over a shortlist that goes stale the moment the index changes.
+func pickWithFzfLive(dbPath, initialQuery string, limit int, onlyPDF, onlyIMG, searchBody bool, sinceFlag, socketPath string, reveal bool) error {
+	fzfPath, err := exec.LookPath("fzf")
+	if err != nil {
+		return fmt.Errorf("fzf not found. Install with: brew install fzf")
+	}
+
+	openCmd := "open {}"
+	if reveal {
+		openCmd = "open -R {}"
+	}
+	reload := reloadCommand(dbPath, limit, onlyPDF, onlyIMG, searchBody, sinceFlag, socketPath)
+
+	cmd := exec.Command(fzfPath,
+		"--prompt", "ðŸ” findex > ",
+		"--height", "40%", "--reverse",
+		"--query", initialQuery,
+		"--disabled",
+		"--bind", "start:reload:"+reload,
+		"--bind", "change:reload:"+reload,
+		"--bind", "enter:become("+openCmd+")",
+	)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// reloadCommand builds the shell command fzf's reload bind re-runs on every
+// keystroke, passing `{q}` through for fzf to substitute with its own query
+// box contents.
+func reloadCommand(dbPath string, limit int, onlyPDF, onlyIMG, searchBody bool, sinceFlag, socketPath string) string {
+	parts := []string{
+		shellQuote(exePath()), "q", "--quiet",
+		"--limit", strconv.Itoa(limit),
+		"--db", shellQuote(dbPath),
+	}
+	if sinceFlag != "" {
+		parts = append(parts, "--since", sinceFlag)
+	}
+	if onlyPDF {
+		parts = append(parts, "--pdf")
+	}
+	if onlyIMG {
+		parts = append(parts, "--img")
+	}
+	if searchBody {
+		parts = append(parts, "--content")
+	}
+	if socketPath != "" {
+		parts = append(parts, "--socket", shellQuote(socketPath))
+	}
+	// fzf only shell-escapes {q} itself when the placeholder is quoted in
+	// the template; left bare, whatever the user types gets spliced
+	// straight into the reload shell command on every keystroke.
+	parts = append(parts, "'{q}'")
+	return strings.Join(parts, " ")
+}
+
+func exePath() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "findex"
+	}
+	return exe
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func isTTY(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
 /* ---------- helpers ---------- */
 
 type multiFlag []string